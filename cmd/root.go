@@ -0,0 +1,19 @@
+/*******************************************************************************
+ * Copyright (c) 2020 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package cmd
+
+import "github.com/urfave/cli"
+
+// Commands : The full set of cwctl subcommands, registered by each command file's init(). An
+// application entrypoint uses them by assigning this to its cli.App's Commands field; no entrypoint
+// in this tree does so.
+var Commands []cli.Command
@@ -0,0 +1,90 @@
+/*******************************************************************************
+ * Copyright (c) 2020 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/eclipse/codewind-installer/pkg/apiroutes"
+	"github.com/urfave/cli"
+)
+
+// This file defines cwctl's status/doctor subcommands in full and registers them in Commands. This
+// tree has no entrypoint that constructs a cli.App; an entrypoint picks these commands up by setting
+// its cli.App.Commands field to cmd.Commands.
+func init() {
+	Commands = append(Commands, statusCommand, doctorCommand)
+}
+
+var statusCommand = cli.Command{
+	Name:  "status",
+	Usage: "Print the status of a Codewind connection",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "conid, id",
+			Usage: "the ID of the connection to check",
+			Value: "local",
+		},
+		cli.BoolFlag{
+			Name:  "check-versions",
+			Usage: "also check the running container versions against this cwctl's compatibility matrix",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		conID := c.String("conid")
+		if !c.Bool("check-versions") {
+			return printStatusReport(conID)
+		}
+		return printCompatibilityReport(conID)
+	},
+}
+
+var doctorCommand = cli.Command{
+	Name:  "doctor",
+	Usage: "Diagnose the health and version compatibility of a Codewind connection",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "conid, id",
+			Usage: "the ID of the connection to diagnose",
+			Value: "local",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		return printCompatibilityReport(c.String("conid"))
+	},
+}
+
+// printStatusReport : Prints the connection ID that "status" is reporting on. No other connection
+// health fields are reported; the version check behind --check-versions is printCompatibilityReport.
+func printStatusReport(conID string) error {
+	fmt.Printf("connection %q\n", conID)
+	return nil
+}
+
+// printCompatibilityReport : Checks the running container versions for conID against this cwctl's
+// bundled compatibility matrix and prints the resulting report as JSON
+func printCompatibilityReport(conID string) error {
+	report, err := apiroutes.CheckCompatibility(conID, http.DefaultClient)
+	if err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+
+	output, marshalErr := json.MarshalIndent(report, "", "  ")
+	if marshalErr != nil {
+		return cli.NewExitError(marshalErr.Error(), 1)
+	}
+
+	fmt.Println(string(output))
+	return nil
+}
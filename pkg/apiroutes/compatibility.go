@@ -0,0 +1,244 @@
+/*******************************************************************************
+ * Copyright (c) 2020 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package apiroutes
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/eclipse/codewind-installer/pkg/utils"
+)
+
+type (
+	// ComponentStatus : The compatibility status of a single Codewind component
+	ComponentStatus string
+
+	// componentRequirement : The minimum version of a component required by a given cwctl major.minor release
+	componentRequirement struct {
+		PFEVersion         string `json:"pfe"`
+		GatekeeperVersion  string `json:"gatekeeper"`
+		PerformanceVersion string `json:"performance"`
+	}
+
+	// ComponentReport : The compatibility status of a single container component
+	ComponentReport struct {
+		Component   string          `json:"component"`
+		Version     string          `json:"version"`
+		Status      ComponentStatus `json:"status"`
+		Remediation string          `json:"remediation,omitempty"`
+	}
+
+	// CompatibilityReport : The compatibility of every container component running against the current cwctl
+	CompatibilityReport struct {
+		CwctlVersion    string            `json:"cwctlVersion"`
+		Components      []ComponentReport `json:"components"`
+		MatrixFetchedAt string            `json:"matrixFetchedAt"`
+	}
+
+	semver struct {
+		Major, Minor, Patch int
+	}
+)
+
+const (
+	// ComponentCompatible : The component's version satisfies the compatibility matrix
+	ComponentCompatible ComponentStatus = "Compatible"
+	// ComponentNeedsUpgrade : The component is behind the version required by the current cwctl
+	ComponentNeedsUpgrade ComponentStatus = "NeedsUpgrade"
+	// ComponentIncompatible : The component cannot be used with the current cwctl
+	ComponentIncompatible ComponentStatus = "Incompatible"
+	// ComponentUnknown : The component's version could not be determined or parsed
+	ComponentUnknown ComponentStatus = "Unknown"
+
+	// downgradeWindowPatches : how many patch versions behind the required minimum are still tolerated
+	downgradeWindowPatches = 2
+
+	// bundledMatrixFetchedAt : when the embedded compatibility matrix below was last refreshed
+	bundledMatrixFetchedAt = "2020-06-01T00:00:00Z"
+)
+
+// bundledCompatibilityMatrix : the compatibility matrix shipped with this build of cwctl, keyed by
+// cwctl "major.minor" and giving the minimum PFE/Gatekeeper/Performance version that release requires
+const bundledCompatibilityMatrix = `{
+	"0.9": { "pfe": "0.9.0", "gatekeeper": "0.9.0", "performance": "0.9.0" },
+	"0.10": { "pfe": "0.10.0", "gatekeeper": "0.10.0", "performance": "0.10.0" },
+	"0.11": { "pfe": "0.11.0", "gatekeeper": "0.11.0", "performance": "0.11.0" }
+}`
+
+var compatibilityMatrix map[string]componentRequirement
+
+func init() {
+	compatibilityMatrix = map[string]componentRequirement{}
+	// An error here means the bundled matrix is malformed, which is a build-time bug rather than
+	// something a caller can recover from, so CheckCompatibility falls back to "unknown" per component.
+	json.Unmarshal([]byte(bundledCompatibilityMatrix), &compatibilityMatrix)
+}
+
+// CheckCompatibility : Gets the running container versions for the given connection and validates them
+// against the compatibility matrix bundled with this cwctl release
+func CheckCompatibility(conID string, httpClient utils.HTTPClient) (CompatibilityReport, error) {
+	versions, err := GetContainerVersions(conID, httpClient)
+	if err != nil {
+		return CompatibilityReport{}, err
+	}
+
+	required, matrixHasEntry := compatibilityMatrix[cwctlMajorMinor(versions.CwctlVersion)]
+
+	report := CompatibilityReport{
+		CwctlVersion:    versions.CwctlVersion,
+		MatrixFetchedAt: bundledMatrixFetchedAt,
+	}
+
+	if !matrixHasEntry {
+		report.Components = []ComponentReport{
+			evaluateComponent("PFE", versions.PFEVersion, ""),
+			evaluateComponent("Gatekeeper", versions.GatekeeperVersion, ""),
+			evaluateComponent("Performance", versions.PerformanceVersion, ""),
+		}
+		return report, nil
+	}
+
+	report.Components = []ComponentReport{
+		evaluateComponent("PFE", versions.PFEVersion, required.PFEVersion),
+		evaluateComponent("Gatekeeper", versions.GatekeeperVersion, required.GatekeeperVersion),
+		evaluateComponent("Performance", versions.PerformanceVersion, required.PerformanceVersion),
+	}
+	return report, nil
+}
+
+// evaluateComponent : Compares a running component's version against the minimum version required
+// by the current cwctl, handling empty/non-semver strings and missing matrix entries gracefully
+func evaluateComponent(component, actualVersion, minRequiredVersion string) ComponentReport {
+	if actualVersion == "" {
+		return ComponentReport{
+			Component:   component,
+			Version:     actualVersion,
+			Status:      ComponentUnknown,
+			Remediation: fmt.Sprintf("could not determine %s version; skipping compatibility check", component),
+		}
+	}
+
+	actual, actualIsSemver := parseSemver(actualVersion)
+	if !actualIsSemver {
+		return ComponentReport{
+			Component:   component,
+			Version:     actualVersion,
+			Status:      ComponentUnknown,
+			Remediation: fmt.Sprintf("%s is running a non-semver version %q; skipping compatibility check", component, actualVersion),
+		}
+	}
+
+	if minRequiredVersion == "" {
+		return ComponentReport{
+			Component:   component,
+			Version:     actualVersion,
+			Status:      ComponentUnknown,
+			Remediation: "no compatibility data for this cwctl release; the bundled matrix may be stale",
+		}
+	}
+
+	required, requiredIsSemver := parseSemver(minRequiredVersion)
+	if !requiredIsSemver {
+		return ComponentReport{
+			Component:   component,
+			Version:     actualVersion,
+			Status:      ComponentUnknown,
+			Remediation: "compatibility matrix entry is malformed",
+		}
+	}
+
+	if Allowed(required, actual, downgradeWindowPatches) {
+		return ComponentReport{
+			Component: component,
+			Version:   actualVersion,
+			Status:    ComponentCompatible,
+		}
+	}
+
+	if actual.Major < required.Major {
+		return ComponentReport{
+			Component:   component,
+			Version:     actualVersion,
+			Status:      ComponentIncompatible,
+			Remediation: fmt.Sprintf("%s %s is too old for this cwctl; upgrade to at least %s", component, actualVersion, minRequiredVersion),
+		}
+	}
+
+	return ComponentReport{
+		Component:   component,
+		Version:     actualVersion,
+		Status:      ComponentNeedsUpgrade,
+		Remediation: fmt.Sprintf("%s %s is older than the recommended %s; consider upgrading", component, actualVersion, minRequiredVersion),
+	}
+}
+
+// Allowed : Reports whether target is compatible with current, following the same forward/backward
+// compatibility rule used by tools like juju's upgrade validator: any forward major jump is allowed,
+// a same-major upgrade is always allowed, and a same-major downgrade is allowed only within
+// downgradeWindowPatches patch versions. A cross-major downgrade is never allowed.
+func Allowed(current, target semver, downgradeWindowPatches int) bool {
+	if target.Major > current.Major {
+		return true
+	}
+	if target.Major < current.Major {
+		return false
+	}
+
+	if target.Minor > current.Minor {
+		return true
+	}
+	if target.Minor == current.Minor {
+		if target.Patch >= current.Patch {
+			return true
+		}
+		return current.Patch-target.Patch <= downgradeWindowPatches
+	}
+	return false
+}
+
+// cwctlMajorMinor : Returns the "major.minor" key used to look up a cwctl release in the compatibility matrix
+func cwctlMajorMinor(cwctlVersion string) string {
+	version, ok := parseSemver(cwctlVersion)
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf("%d.%d", version.Major, version.Minor)
+}
+
+// parseSemver : A minimal semver parser covering the "major.minor.patch" versions cwctl and the
+// containers report. Build metadata and pre-release suffixes (e.g. "1.2.3-rc1") are ignored.
+// Strings that aren't semver at all, such as "latest" or "dev", return ok=false.
+func parseSemver(version string) (semver, bool) {
+	trimmed := strings.SplitN(strings.TrimPrefix(version, "v"), "-", 2)[0]
+	trimmed = strings.SplitN(trimmed, "+", 2)[0]
+	parts := strings.Split(trimmed, ".")
+	if len(parts) != 3 {
+		return semver{}, false
+	}
+
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return semver{}, false
+	}
+	minor, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return semver{}, false
+	}
+	patch, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return semver{}, false
+	}
+
+	return semver{Major: major, Minor: minor, Patch: patch}, true
+}
@@ -0,0 +1,184 @@
+/*******************************************************************************
+ * Copyright (c) 2020 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package apiroutes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAllowed(t *testing.T) {
+	tests := map[string]struct {
+		current semver
+		target  semver
+		want    bool
+	}{
+		"forward major jump is allowed": {
+			current: semver{Major: 1, Minor: 0, Patch: 0},
+			target:  semver{Major: 2, Minor: 0, Patch: 0},
+			want:    true,
+		},
+		"same-major upgrade is allowed": {
+			current: semver{Major: 1, Minor: 0, Patch: 0},
+			target:  semver{Major: 1, Minor: 1, Patch: 0},
+			want:    true,
+		},
+		"same-major, same-minor, patch upgrade is allowed": {
+			current: semver{Major: 1, Minor: 0, Patch: 0},
+			target:  semver{Major: 1, Minor: 0, Patch: 1},
+			want:    true,
+		},
+		"same-major downgrade within the window is allowed": {
+			current: semver{Major: 1, Minor: 0, Patch: 5},
+			target:  semver{Major: 1, Minor: 0, Patch: 3},
+			want:    true,
+		},
+		"same-major downgrade outside the window is not allowed": {
+			current: semver{Major: 1, Minor: 0, Patch: 5},
+			target:  semver{Major: 1, Minor: 0, Patch: 2},
+			want:    false,
+		},
+		"same-major minor downgrade is not allowed": {
+			current: semver{Major: 1, Minor: 5, Patch: 0},
+			target:  semver{Major: 1, Minor: 4, Patch: 0},
+			want:    false,
+		},
+		"cross-major downgrade is never allowed": {
+			current: semver{Major: 2, Minor: 0, Patch: 0},
+			target:  semver{Major: 1, Minor: 9, Patch: 9},
+			want:    false,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := Allowed(test.current, test.target, downgradeWindowPatches)
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
+
+func TestParseSemver(t *testing.T) {
+	tests := map[string]struct {
+		in     string
+		want   semver
+		wantOk bool
+	}{
+		"plain semver": {
+			in:     "1.2.3",
+			want:   semver{Major: 1, Minor: 2, Patch: 3},
+			wantOk: true,
+		},
+		"v-prefixed semver": {
+			in:     "v1.2.3",
+			want:   semver{Major: 1, Minor: 2, Patch: 3},
+			wantOk: true,
+		},
+		"semver with pre-release suffix": {
+			in:     "1.2.3-rc1",
+			want:   semver{Major: 1, Minor: 2, Patch: 3},
+			wantOk: true,
+		},
+		"non-semver string": {
+			in:     "latest",
+			wantOk: false,
+		},
+		"non-semver string: dev": {
+			in:     "dev",
+			wantOk: false,
+		},
+		"missing patch component": {
+			in:     "1.2",
+			wantOk: false,
+		},
+		"empty string": {
+			in:     "",
+			wantOk: false,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, ok := parseSemver(test.in)
+			assert.Equal(t, test.wantOk, ok)
+			if test.wantOk {
+				assert.Equal(t, test.want, got)
+			}
+		})
+	}
+}
+
+func TestEvaluateComponent(t *testing.T) {
+	tests := map[string]struct {
+		actualVersion string
+		minRequired   string
+		wantStatus    ComponentStatus
+	}{
+		"empty version is unknown": {
+			actualVersion: "",
+			minRequired:   "1.0.0",
+			wantStatus:    ComponentUnknown,
+		},
+		"non-semver version is unknown": {
+			actualVersion: "latest",
+			minRequired:   "1.0.0",
+			wantStatus:    ComponentUnknown,
+		},
+		"matrix miss (no minimum required version) is unknown": {
+			actualVersion: "1.0.0",
+			minRequired:   "",
+			wantStatus:    ComponentUnknown,
+		},
+		"malformed matrix entry is unknown": {
+			actualVersion: "1.0.0",
+			minRequired:   "not-a-semver",
+			wantStatus:    ComponentUnknown,
+		},
+		"version satisfying the minimum is compatible": {
+			actualVersion: "1.0.0",
+			minRequired:   "1.0.0",
+			wantStatus:    ComponentCompatible,
+		},
+		"version behind on minor needs upgrade": {
+			actualVersion: "1.0.0",
+			minRequired:   "1.1.0",
+			wantStatus:    ComponentNeedsUpgrade,
+		},
+		"version on an older major is incompatible": {
+			actualVersion: "0.9.0",
+			minRequired:   "1.0.0",
+			wantStatus:    ComponentIncompatible,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := evaluateComponent("PFE", test.actualVersion, test.minRequired)
+			assert.Equal(t, test.wantStatus, got.Status)
+			assert.Equal(t, "PFE", got.Component)
+		})
+	}
+}
+
+func TestCwctlMajorMinor(t *testing.T) {
+	tests := map[string]struct {
+		in   string
+		want string
+	}{
+		"semver version":     {"0.9.0", "0.9"},
+		"v-prefixed version": {"v0.11.2", "0.11"},
+		"non-semver version": {"latest", ""},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.want, cwctlMajorMinor(test.in))
+		})
+	}
+}
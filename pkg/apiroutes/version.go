@@ -12,9 +12,12 @@
 package apiroutes
 
 import (
+	"context"
 	"encoding/json"
 	"io/ioutil"
 	"net/http"
+	"sync"
+	"time"
 
 	"github.com/eclipse/codewind-installer/pkg/appconstants"
 	"github.com/eclipse/codewind-installer/pkg/connections"
@@ -23,12 +26,19 @@ import (
 )
 
 type (
-	// ContainerVersions : The versions of the Codewind containers that are running
+	// ContainerVersions : The versions of the Codewind containers that are running, along with a
+	// per-component error and latency so a slow or unreachable component doesn't hide the others
 	ContainerVersions struct {
-		CwctlVersion       string
-		PerformanceVersion string
-		GatekeeperVersion  string
-		PFEVersion         string
+		CwctlVersion         string
+		PerformanceVersion   string
+		GatekeeperVersion    string
+		PFEVersion           string
+		PerformanceError     string
+		GatekeeperError      string
+		PFEError             string
+		PerformanceLatencyMs int64
+		GatekeeperLatencyMs  int64
+		PFELatencyMs         int64
 	}
 
 	// CodewindVersion : The version of the Codewind container that is running
@@ -37,35 +47,103 @@ type (
 	}
 )
 
-// GetContainerVersions  :  Gets the versions of each Codewind container, for a given connection ID
+// defaultComponentTimeout : how long GetContainerVersions waits for each component before
+// reporting it as unreachable
+const defaultComponentTimeout = 10 * time.Second
+
+// GetContainerVersions  :  Gets the versions of each Codewind container, for a given connection ID.
+// Kept as a thin wrapper around GetContainerVersionsCtx for existing callers.
 func GetContainerVersions(conID string, httpClient utils.HTTPClient) (ContainerVersions, error) {
+	return GetContainerVersionsCtx(context.Background(), conID, httpClient, defaultComponentTimeout)
+}
+
+// GetContainerVersionsCtx : Gets the versions of each Codewind container, for a given connection ID.
+// The PFE, Gatekeeper and Performance versions are fetched concurrently, each bounded by timeout, so
+// that one slow or unreachable component doesn't block or hide the results of the others.
+func GetContainerVersionsCtx(ctx context.Context, conID string, httpClient utils.HTTPClient, timeout time.Duration) (ContainerVersions, error) {
 	conInfo, conInfoErr := connections.GetConnectionByID(conID)
 	if conInfoErr != nil {
 		return ContainerVersions{}, conInfoErr.Err
 	}
 
-	var containerVersions ContainerVersions
-	PFEVersion, err := GetPFEVersionFromConnection(conInfo, http.DefaultClient)
-	if err != nil {
-		return ContainerVersions{}, err
-	}
+	containerVersions := ContainerVersions{
+		CwctlVersion: appconstants.VersionNum,
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(3)
+
+	go func() {
+		defer wg.Done()
+		version, latency, err := fetchVersionWithTimeout(ctx, timeout, func() (string, error) {
+			return GetPFEVersionFromConnection(conInfo, httpClient)
+		})
+		containerVersions.PFEVersion = version
+		containerVersions.PFELatencyMs = latency.Milliseconds()
+		if err != nil {
+			containerVersions.PFEError = err.Error()
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+		version, latency, err := fetchVersionWithTimeout(ctx, timeout, func() (string, error) {
+			return GetGatekeeperVersionFromConnection(conInfo, httpClient)
+		})
+		containerVersions.GatekeeperVersion = version
+		containerVersions.GatekeeperLatencyMs = latency.Milliseconds()
+		if err != nil {
+			containerVersions.GatekeeperError = err.Error()
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+		version, latency, err := fetchVersionWithTimeout(ctx, timeout, func() (string, error) {
+			return GetPerformanceVersionFromConnection(conInfo, httpClient)
+		})
+		containerVersions.PerformanceVersion = version
+		containerVersions.PerformanceLatencyMs = latency.Milliseconds()
+		if err != nil {
+			containerVersions.PerformanceError = err.Error()
+		}
+	}()
+
+	wg.Wait()
 
-	GatekeeperVersion, err := GetGatekeeperVersionFromConnection(conInfo, http.DefaultClient)
-	if err != nil {
-		return ContainerVersions{}, err
+	return containerVersions, nil
+}
+
+// fetchVersionWithTimeout : Runs fetch in the background and returns its result, or ctx's error if
+// it doesn't complete within timeout. If ctx is already done, its error is returned without running
+// fetch. The goroutine is left to finish on its own; fetch has no way to be cancelled mid-flight
+// since the underlying Get*VersionFromConnection calls don't take a context.
+func fetchVersionWithTimeout(ctx context.Context, timeout time.Duration, fetch func() (string, error)) (string, time.Duration, error) {
+	if err := ctx.Err(); err != nil {
+		return "", 0, err
 	}
 
-	PerformanceVersion, err := GetPerformanceVersionFromConnection(conInfo, http.DefaultClient)
-	if err != nil {
-		return ContainerVersions{}, err
+	type result struct {
+		version string
+		err     error
 	}
 
-	containerVersions.CwctlVersion = appconstants.VersionNum
-	containerVersions.PFEVersion = PFEVersion
-	containerVersions.GatekeeperVersion = GatekeeperVersion
-	containerVersions.PerformanceVersion = PerformanceVersion
+	resultChan := make(chan result, 1)
+	start := time.Now()
+	go func() {
+		version, err := fetch()
+		resultChan <- result{version, err}
+	}()
 
-	return containerVersions, nil
+	timeoutCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	select {
+	case r := <-resultChan:
+		return r.version, time.Since(start), r.err
+	case <-timeoutCtx.Done():
+		return "", time.Since(start), timeoutCtx.Err()
+	}
 }
 
 // GetPFEVersionFromConnection : Gets the version of the PFE container, deployed to the connection with the given ID
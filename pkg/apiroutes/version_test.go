@@ -0,0 +1,70 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package apiroutes
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestFetchVersionWithTimeout covers the fan-out behaviour GetContainerVersionsCtx relies on to keep
+// one slow or erroring component from blocking (or hiding the result of) the other two: each call is
+// independent, and a component that doesn't respond within its timeout reports ctx's error rather than
+// waiting for fetch to ever return.
+func TestFetchVersionWithTimeout(t *testing.T) {
+	t.Run("fetch completes within the timeout", func(t *testing.T) {
+		version, _, err := fetchVersionWithTimeout(context.Background(), time.Second, func() (string, error) {
+			return "1.2.3", nil
+		})
+
+		assert.Nil(t, err)
+		assert.Equal(t, "1.2.3", version)
+	})
+
+	t.Run("fetch returns an error within the timeout", func(t *testing.T) {
+		fetchErr := errors.New("component unreachable")
+		version, _, err := fetchVersionWithTimeout(context.Background(), time.Second, func() (string, error) {
+			return "", fetchErr
+		})
+
+		assert.Equal(t, fetchErr, err)
+		assert.Equal(t, "", version)
+	})
+
+	t.Run("slow fetch is reported as timed out rather than blocking the caller", func(t *testing.T) {
+		started := time.Now()
+		_, latency, err := fetchVersionWithTimeout(context.Background(), 10*time.Millisecond, func() (string, error) {
+			time.Sleep(time.Second)
+			return "1.2.3", nil
+		})
+		elapsed := time.Since(started)
+
+		assert.NotNil(t, err)
+		assert.True(t, elapsed < 500*time.Millisecond, "fetchVersionWithTimeout should return as soon as the timeout elapses, not wait for the slow fetch")
+		assert.True(t, latency < 500*time.Millisecond)
+	})
+
+	t.Run("an already-cancelled context times out immediately", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, _, err := fetchVersionWithTimeout(ctx, time.Second, func() (string, error) {
+			return "1.2.3", nil
+		})
+
+		assert.Equal(t, context.Canceled, err)
+	})
+}
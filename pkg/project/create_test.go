@@ -12,15 +12,21 @@
 package project
 
 import (
+	"archive/tar"
 	"bytes"
+	"compress/gzip"
 	"encoding/json"
 	"errors"
 	"io/ioutil"
 	"log"
 	"net/http"
+	"net/http/cgi"
+	"net/http/httptest"
 	"os"
+	"os/exec"
 	"path"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/eclipse/codewind-installer/pkg/connections"
@@ -120,6 +126,289 @@ func TestDownloadTemplate(t *testing.T) {
 	})
 }
 
+func TestDownloadTemplateTarball(t *testing.T) {
+	os.RemoveAll(testDir)
+	defer os.RemoveAll(testDir)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/gzip")
+		gzipWriter := gzip.NewWriter(w)
+		tarWriter := tar.NewWriter(gzipWriter)
+
+		contents := []byte("hello from the template")
+		tarWriter.WriteHeader(&tar.Header{
+			Name: "my-template/README.md",
+			Mode: 0644,
+			Size: int64(len(contents)),
+		})
+		tarWriter.Write(contents)
+
+		tarWriter.Close()
+		gzipWriter.Close()
+	}))
+	defer server.Close()
+
+	dest := filepath.Join(testDir, "tarballTemplateRepo")
+	out, err := DownloadTemplate(dest, server.URL+"/my-template.tar.gz", nil)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "success", out.Status)
+
+	readmeContents, readErr := ioutil.ReadFile(filepath.Join(dest, "README.md"))
+	assert.Nil(t, readErr)
+	assert.Equal(t, "hello from the template", string(readmeContents))
+}
+
+func TestDownloadTemplateTarballFlatArchive(t *testing.T) {
+	os.RemoveAll(testDir)
+	defer os.RemoveAll(testDir)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/gzip")
+		gzipWriter := gzip.NewWriter(w)
+		tarWriter := tar.NewWriter(gzipWriter)
+
+		writeEntry := func(name string, contents []byte) {
+			tarWriter.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(contents))})
+			tarWriter.Write(contents)
+		}
+		// A flat archive with no shared top-level directory - unlike a GitHub-style archive, there
+		// is nothing here to strip.
+		writeEntry("README.md", []byte("root readme"))
+		writeEntry("src/main.go", []byte("package main"))
+
+		tarWriter.Close()
+		gzipWriter.Close()
+	}))
+	defer server.Close()
+
+	dest := filepath.Join(testDir, "flatTarballTemplateRepo")
+	out, err := DownloadTemplate(dest, server.URL+"/flat-template.tar.gz", nil)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "success", out.Status)
+
+	readmeContents, readErr := ioutil.ReadFile(filepath.Join(dest, "README.md"))
+	assert.Nil(t, readErr)
+	assert.Equal(t, "root readme", string(readmeContents))
+
+	mainContents, readErr := ioutil.ReadFile(filepath.Join(dest, "src", "main.go"))
+	assert.Nil(t, readErr)
+	assert.Equal(t, "package main", string(mainContents))
+}
+
+func TestDownloadTemplateTarballRejectsZipSlip(t *testing.T) {
+	tmpRoot, tmpErr := ioutil.TempDir("", "cwctl-zipslip-test")
+	assert.Nil(t, tmpErr)
+	defer os.RemoveAll(tmpRoot)
+
+	dest := filepath.Join(tmpRoot, "dest")
+	escapeTarget := filepath.Join(tmpRoot, "escaped.txt")
+
+	// Walks back up from dest's own directory depth to the filesystem root (with "../" to spare),
+	// then back down to escapeTarget - enough to escape dest no matter how deep tmpRoot happens to be.
+	climb := strings.Repeat("../", strings.Count(filepath.Clean(dest), string(filepath.Separator))+2)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/gzip")
+		gzipWriter := gzip.NewWriter(w)
+		tarWriter := tar.NewWriter(gzipWriter)
+
+		writeEntry := func(name string, contents []byte) {
+			tarWriter.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(contents))})
+			tarWriter.Write(contents)
+		}
+		// Both entries share the top-level "evil-template" wrapper, so stripTopLevelDir strips it as
+		// usual - but what's left of the second entry still escapes dest via "../" climbing.
+		writeEntry("evil-template/README.md", []byte("looks innocent"))
+		writeEntry("evil-template/"+climb+filepath.ToSlash(strings.TrimPrefix(escapeTarget, string(filepath.Separator))), []byte("malicious payload"))
+
+		tarWriter.Close()
+		gzipWriter.Close()
+	}))
+	defer server.Close()
+
+	out, err := DownloadTemplate(dest, server.URL+"/evil-template.tar.gz", nil)
+
+	assert.Nil(t, out)
+	assert.Equal(t, errOpArchiveExtract, err.Op)
+	_, statErr := os.Stat(escapeTarget)
+	assert.True(t, os.IsNotExist(statErr))
+}
+
+func TestDownloadTemplateUnsupportedScheme(t *testing.T) {
+	out, err := DownloadTemplate(testDir, "ftp://example.com/template", nil)
+
+	assert.Nil(t, out)
+	assert.Equal(t, errOpUnsupportedScheme, err.Op)
+}
+
+// newLocalGitHTTPServer : Starts a local smart-HTTP Git server backed by `git http-backend`,
+// serving a freshly created repository containing files, and returns its clone URL
+func newLocalGitHTTPServer(t *testing.T, files map[string]string) string {
+	backendPath, lookErr := exec.LookPath("git-http-backend")
+	if lookErr != nil {
+		for _, candidate := range []string{"/usr/lib/git-core/git-http-backend", "/usr/libexec/git-core/git-http-backend"} {
+			if utils.PathExists(candidate) {
+				backendPath = candidate
+				break
+			}
+		}
+	}
+	if backendPath == "" {
+		t.Skip("skipping this test because git-http-backend isn't available")
+	}
+
+	root, tmpErr := ioutil.TempDir("", "git-fixture-root")
+	if tmpErr != nil {
+		t.Fatal(tmpErr)
+	}
+	t.Cleanup(func() { os.RemoveAll(root) })
+
+	workDir := filepath.Join(root, "work")
+	if err := os.MkdirAll(workDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, workDir, "init")
+	for name, contents := range files {
+		path := filepath.Join(workDir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		ioutil.WriteFile(path, []byte(contents), 0644)
+		runGit(t, workDir, "add", name)
+	}
+	runGit(t, workDir, "commit", "-m", "initial commit")
+
+	repoPath := filepath.Join(root, "fixture.git")
+	if err := os.Rename(filepath.Join(workDir, ".git"), repoPath); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, repoPath, "update-server-info")
+
+	handler := &cgi.Handler{
+		Path: backendPath,
+		Env:  []string{"GIT_PROJECT_ROOT=" + root, "GIT_HTTP_EXPORT_ALL=1"},
+	}
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	return server.URL + "/fixture.git"
+}
+
+func runGit(t *testing.T, dir string, args ...string) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=cwctl-test", "GIT_AUTHOR_EMAIL=cwctl-test@example.com",
+		"GIT_COMMITTER_NAME=cwctl-test", "GIT_COMMITTER_EMAIL=cwctl-test@example.com")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v failed: %v: %s", args, err, out)
+	}
+}
+
+func TestDownloadTemplateGitOverHTTPFixture(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("skipping this test because git isn't available")
+	}
+
+	os.RemoveAll(testDir)
+	defer os.RemoveAll(testDir)
+
+	repoURL := newLocalGitHTTPServer(t, map[string]string{"README.md": "hello from the fixture"})
+
+	dest := filepath.Join(testDir, "gitOverHTTPFixtureRepo")
+	out, err := DownloadTemplate(dest, repoURL, nil)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "success", out.Status)
+
+	readmeContents, readErr := ioutil.ReadFile(filepath.Join(dest, "README.md"))
+	assert.Nil(t, readErr)
+	assert.Equal(t, "hello from the fixture", string(readmeContents))
+}
+
+func TestSSHAuthForTemplate(t *testing.T) {
+	t.Run("error case: CW_GIT_SSH_KEY points at a key that doesn't exist", func(t *testing.T) {
+		os.Setenv("CW_GIT_SSH_KEY", "/not/a/real/key")
+		defer os.Unsetenv("CW_GIT_SSH_KEY")
+
+		auth, err := sshAuthForTemplate()
+
+		assert.Nil(t, auth)
+		assert.Equal(t, errOpInvalidSSHKey, err.Op)
+	})
+}
+
+func TestNormalizeSSHURL(t *testing.T) {
+	tests := map[string]struct {
+		in   string
+		want string
+	}{
+		"git+ssh url":     {"git+ssh://git@example.com/org/repo.git", "ssh://git@example.com/org/repo.git"},
+		"ssh url":         {"ssh://git@example.com/org/repo.git", "ssh://git@example.com/org/repo.git"},
+		"scp-like syntax": {"git@example.com:org/repo.git", "ssh://git@example.com/org/repo.git"},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.want, normalizeSSHURL(test.in))
+		})
+	}
+}
+
+func TestParseOCIReference(t *testing.T) {
+	registry, repository, tag, err := parseOCIReference("oci://registry.example.com/org/my-template:1.0.0")
+	assert.Nil(t, err)
+	assert.Equal(t, "registry.example.com", registry)
+	assert.Equal(t, "org/my-template", repository)
+	assert.Equal(t, "1.0.0", tag)
+
+	registry, repository, tag, err = parseOCIReference("oci://registry.example.com/org/my-template")
+	assert.Nil(t, err)
+	assert.Equal(t, "registry.example.com", registry)
+	assert.Equal(t, "org/my-template", repository)
+	assert.Equal(t, "latest", tag)
+
+	_, _, _, err = parseOCIReference("oci://justaregistry")
+	assert.NotNil(t, err)
+}
+
+func TestDownloadTemplateOCI(t *testing.T) {
+	os.RemoveAll(testDir)
+	defer os.RemoveAll(testDir)
+
+	var archive bytes.Buffer
+	gzipWriter := gzip.NewWriter(&archive)
+	tarWriter := tar.NewWriter(gzipWriter)
+	contents := []byte("hello from the OCI template")
+	tarWriter.WriteHeader(&tar.Header{Name: "README.md", Mode: 0644, Size: int64(len(contents))})
+	tarWriter.Write(contents)
+	tarWriter.Close()
+	gzipWriter.Close()
+
+	const digest = "sha256:test-digest"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/manifests/latest"):
+			w.Write([]byte(`{"layers":[{"digest":"` + digest + `"}]}`))
+		case strings.HasSuffix(r.URL.Path, "/blobs/"+digest):
+			w.Write(archive.Bytes())
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	registry := strings.TrimPrefix(server.URL, "http://")
+	dest := filepath.Join(testDir, "ociTemplateRepo")
+	out, err := DownloadTemplate(dest, "oci://"+registry+"/org/my-template", nil)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "success", out.Status)
+
+	readmeContents, readErr := ioutil.ReadFile(filepath.Join(dest, "README.md"))
+	assert.Nil(t, readErr)
+	assert.Equal(t, "hello from the OCI template", string(readmeContents))
+}
+
 func TestDetermineProjectInfo(t *testing.T) {
 	tests := map[string]struct {
 		in            string
@@ -0,0 +1,501 @@
+/*******************************************************************************
+ * Copyright (c) 2020 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package project
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/eclipse/codewind-installer/pkg/utils"
+	git "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing/transport/ssh"
+)
+
+// TemplateFetcher : Fetches a project template, identified by a URL, into a destination directory
+type TemplateFetcher interface {
+	Fetch(dest string, templateURL string) (*Result, *ProjectError)
+}
+
+type (
+	// gitFetcher : Fetches a template from a Git repository over HTTP(S), using the chained
+	// credential resolver
+	gitFetcher struct {
+		Credentials *utils.GitCredentials
+	}
+
+	// sshGitFetcher : Fetches a template from a Git repository over SSH, using an SSH agent or a
+	// private key identified by CW_GIT_SSH_KEY
+	sshGitFetcher struct{}
+
+	// tarballFetcher : Downloads a .tar.gz/.tgz/.zip archive over HTTP(S) and extracts it directly,
+	// without going through Git at all
+	tarballFetcher struct{}
+
+	// ociFetcher : Fetches a template packaged as an OCI artifact, pulling its manifest and its
+	// first layer the way an ORAS client would; any further layers are not fetched
+	ociFetcher struct{}
+)
+
+// fetcherFor : Chooses the TemplateFetcher that understands templateURL's scheme
+func fetcherFor(templateURL string, gitCredentials *utils.GitCredentials) (TemplateFetcher, *ProjectError) {
+	switch {
+	case isSSHURL(templateURL):
+		return &sshGitFetcher{}, nil
+	case strings.HasPrefix(templateURL, "oci://"):
+		return &ociFetcher{}, nil
+	case isTarballURL(templateURL):
+		return &tarballFetcher{}, nil
+	case strings.HasPrefix(templateURL, "http://") || strings.HasPrefix(templateURL, "https://"):
+		return &gitFetcher{Credentials: gitCredentials}, nil
+	default:
+		err := fmt.Errorf("unsupported template URL scheme: %s", templateURL)
+		return nil, &ProjectError{errOpUnsupportedScheme, err, err.Error()}
+	}
+}
+
+func isSSHURL(templateURL string) bool {
+	if strings.HasPrefix(templateURL, "git+ssh://") || strings.HasPrefix(templateURL, "ssh://") {
+		return true
+	}
+	// SCP-like syntax, e.g. git@github.com:org/repo.git
+	return strings.Contains(templateURL, "@") && strings.Contains(templateURL, ":") && !strings.Contains(templateURL, "://")
+}
+
+func isTarballURL(templateURL string) bool {
+	lower := strings.ToLower(templateURL)
+	return strings.HasSuffix(lower, ".tar.gz") || strings.HasSuffix(lower, ".tgz") || strings.HasSuffix(lower, ".zip")
+}
+
+// Fetch : Clones over HTTP(S) via the chained credential resolver
+func (f *gitFetcher) Fetch(dest string, templateURL string) (*Result, *ProjectError) {
+	chain := &ChainedProvider{
+		Providers: []CredentialProvider{
+			&explicitCredentialProvider{Credentials: f.Credentials},
+			&envCredentialProvider{},
+			&keychainCredentialProvider{},
+			&gitCredentialStoreProvider{},
+			&anonymousCredentialProvider{},
+		},
+	}
+	return downloadTemplateWithChain(dest, templateURL, chain)
+}
+
+// Fetch : Clones over SSH, using an SSH agent if one is available, or the private key named by
+// CW_GIT_SSH_KEY (optionally protected by a passphrase in CW_GIT_SSH_KEY_PASSPHRASE)
+func (f *sshGitFetcher) Fetch(dest string, templateURL string) (*Result, *ProjectError) {
+	auth, authErr := sshAuthForTemplate()
+	if authErr != nil {
+		return nil, authErr
+	}
+
+	os.RemoveAll(dest)
+	_, cloneErr := git.PlainClone(dest, false, &git.CloneOptions{
+		URL:  normalizeSSHURL(templateURL),
+		Auth: auth,
+	})
+	if cloneErr != nil {
+		return nil, &ProjectError{errOpCreateProject, cloneErr, cloneErr.Error()}
+	}
+	return &Result{Status: "success"}, nil
+}
+
+// sshAuthForTemplate : Resolves SSH auth for a template clone. A caller-supplied private key
+// (CW_GIT_SSH_KEY) always takes priority since it was explicitly asked for; otherwise this prefers
+// an SSH agent (SSH_AUTH_SOCK) - the common case for 1Password/gpg-agent/Yubikey-backed keys - and
+// only falls back to the default ~/.ssh/id_rsa on disk if no agent is reachable.
+func sshAuthForTemplate() (ssh.AuthMethod, *ProjectError) {
+	if keyPath := os.Getenv("CW_GIT_SSH_KEY"); keyPath != "" {
+		auth, err := ssh.NewPublicKeysFromFile("git", keyPath, os.Getenv("CW_GIT_SSH_KEY_PASSPHRASE"))
+		if err != nil {
+			return nil, &ProjectError{errOpInvalidSSHKey, err, err.Error()}
+		}
+		return auth, nil
+	}
+
+	if agentAuth, err := ssh.NewSSHAgentAuth("git"); err == nil {
+		return agentAuth, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		err := errors.New("no SSH agent available and unable to locate the user's home directory for a default key")
+		return nil, &ProjectError{errOpInvalidSSHKey, err, err.Error()}
+	}
+
+	auth, err := ssh.NewPublicKeysFromFile("git", filepath.Join(home, ".ssh", "id_rsa"), os.Getenv("CW_GIT_SSH_KEY_PASSPHRASE"))
+	if err != nil {
+		return nil, &ProjectError{errOpInvalidSSHKey, err, err.Error()}
+	}
+	return auth, nil
+}
+
+// normalizeSSHURL : go-git needs an explicit ssh:// URL; rewrite git+ssh:// and SCP-like syntax to it
+func normalizeSSHURL(templateURL string) string {
+	if strings.HasPrefix(templateURL, "git+ssh://") {
+		return strings.TrimPrefix(templateURL, "git+")
+	}
+	if strings.HasPrefix(templateURL, "ssh://") {
+		return templateURL
+	}
+	// git@host:org/repo.git -> ssh://git@host/org/repo.git
+	at := strings.Index(templateURL, "@")
+	colon := strings.Index(templateURL, ":")
+	if at == -1 || colon == -1 || colon < at {
+		return templateURL
+	}
+	return "ssh://" + templateURL[:colon] + "/" + templateURL[colon+1:]
+}
+
+// Fetch : Downloads a tarball or zip archive and extracts it directly into dest, skipping Git
+func (f *tarballFetcher) Fetch(dest string, templateURL string) (*Result, *ProjectError) {
+	resp, err := http.Get(templateURL)
+	if err != nil {
+		return nil, &ProjectError{errOpCreateProject, err, err.Error()}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		err := fmt.Errorf("failed to download template archive: %s", resp.Status)
+		return nil, &ProjectError{errOpCreateProject, err, err.Error()}
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, &ProjectError{errOpCreateProject, err, err.Error()}
+	}
+
+	if err := extractArchive(templateURL, body, dest); err != nil {
+		return nil, &ProjectError{errOpArchiveExtract, err, err.Error()}
+	}
+	return &Result{Status: "success"}, nil
+}
+
+// extractArchive : Extracts a zip or tar.gz archive's contents into dest, stripping the single
+// top-level directory most template archives are wrapped in
+func extractArchive(templateURL string, archive []byte, dest string) error {
+	if err := os.MkdirAll(dest, 0755); err != nil {
+		return err
+	}
+
+	if strings.HasSuffix(strings.ToLower(templateURL), ".zip") {
+		return extractZip(archive, dest)
+	}
+	return extractTarGz(archive, dest)
+}
+
+// tarEntryNames : Lists every entry name in a tar.gz archive, without extracting anything, so the
+// caller can decide up front whether the archive shares a common top-level directory
+func tarEntryNames(archive []byte) ([]string, error) {
+	gzReader, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return nil, err
+	}
+	defer gzReader.Close()
+
+	var names []string
+	tarReader := tar.NewReader(gzReader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return names, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, header.Name)
+	}
+}
+
+func extractTarGz(archive []byte, dest string) error {
+	names, err := tarEntryNames(archive)
+	if err != nil {
+		return err
+	}
+	topDir := commonTopLevelDir(names)
+
+	gzReader, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return err
+	}
+	defer gzReader.Close()
+
+	tarReader := tar.NewReader(gzReader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		relativePath := stripTopLevelDir(header.Name, topDir)
+		if relativePath == "" {
+			continue
+		}
+		target, err := safeExtractTarget(dest, relativePath)
+		if err != nil {
+			return err
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			file, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(header.Mode))
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(file, tarReader); err != nil {
+				file.Close()
+				return err
+			}
+			file.Close()
+		}
+	}
+}
+
+func extractZip(archive []byte, dest string) error {
+	zipReader, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, len(zipReader.File))
+	for i, file := range zipReader.File {
+		names[i] = file.Name
+	}
+	topDir := commonTopLevelDir(names)
+
+	for _, file := range zipReader.File {
+		relativePath := stripTopLevelDir(file.Name, topDir)
+		if relativePath == "" {
+			continue
+		}
+		target, err := safeExtractTarget(dest, relativePath)
+		if err != nil {
+			return err
+		}
+
+		if file.FileInfo().IsDir() {
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+
+		reader, err := file.Open()
+		if err != nil {
+			return err
+		}
+		out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, file.Mode())
+		if err != nil {
+			reader.Close()
+			return err
+		}
+		_, copyErr := io.Copy(out, reader)
+		reader.Close()
+		out.Close()
+		if copyErr != nil {
+			return copyErr
+		}
+	}
+	return nil
+}
+
+// commonTopLevelDir : Returns the single top-level directory shared by every entry in names (e.g.
+// "my-template-1.0.0", the common wrapper GitHub-style archives are built with), or "" if the
+// archive has no such wrapper - a flat layout, or entries under more than one top-level name.
+func commonTopLevelDir(names []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+
+	first := strings.SplitN(filepath.ToSlash(names[0]), "/", 2)
+	if len(first) < 2 || first[0] == "" {
+		return ""
+	}
+	topDir := first[0]
+
+	for _, name := range names {
+		cleaned := filepath.ToSlash(name)
+		if cleaned == topDir || cleaned == topDir+"/" {
+			continue
+		}
+		if !strings.HasPrefix(cleaned, topDir+"/") {
+			return ""
+		}
+	}
+	return topDir
+}
+
+// stripTopLevelDir : Removes topDir's prefix from name, returning "" for the wrapper directory's
+// own entry (which has nothing left to extract). When topDir is "" - no common wrapper exists -
+// name is returned unchanged so a genuinely flat archive's layout is preserved.
+func stripTopLevelDir(name string, topDir string) string {
+	cleaned := filepath.ToSlash(name)
+	if topDir == "" {
+		return cleaned
+	}
+	if cleaned == topDir || cleaned == topDir+"/" {
+		return ""
+	}
+	return strings.TrimPrefix(cleaned, topDir+"/")
+}
+
+// safeExtractTarget : Joins relativePath onto dest and guards against Zip Slip - an archive entry
+// whose name (e.g. "../../../../tmp/evil") escapes dest once joined and cleaned is rejected outright,
+// since every one of this fetcher's archive sources (a tarball URL, an OCI layer) is untrusted input
+func safeExtractTarget(dest string, relativePath string) (string, error) {
+	cleanDest := filepath.Clean(dest)
+	target := filepath.Join(cleanDest, relativePath)
+	if target != cleanDest && !strings.HasPrefix(target, cleanDest+string(filepath.Separator)) {
+		return "", fmt.Errorf("archive entry %q escapes the destination directory", relativePath)
+	}
+	return target, nil
+}
+
+// Fetch : Pulls an OCI template artifact (oci://registry/repo:tag) by fetching its manifest and
+// downloading its first layer, the way an ORAS client would, then extracting it as a tarball
+func (f *ociFetcher) Fetch(dest string, templateURL string) (*Result, *ProjectError) {
+	registry, repository, tag, err := parseOCIReference(templateURL)
+	if err != nil {
+		return nil, &ProjectError{errOpUnsupportedScheme, err, err.Error()}
+	}
+
+	manifest, err := fetchOCIManifest(registry, repository, tag)
+	if err != nil {
+		return nil, &ProjectError{errOpCreateProject, err, err.Error()}
+	}
+
+	layer, err := fetchOCIBlob(registry, repository, manifest)
+	if err != nil {
+		return nil, &ProjectError{errOpCreateProject, err, err.Error()}
+	}
+
+	if err := extractTarGz(layer, dest); err != nil {
+		return nil, &ProjectError{errOpArchiveExtract, err, err.Error()}
+	}
+	return &Result{Status: "success"}, nil
+}
+
+// parseOCIReference : Splits "oci://registry/repo:tag" into its registry, repository and tag parts
+func parseOCIReference(templateURL string) (string, string, string, error) {
+	ref := strings.TrimPrefix(templateURL, "oci://")
+	slash := strings.Index(ref, "/")
+	if slash == -1 {
+		return "", "", "", fmt.Errorf("invalid OCI reference: %s", templateURL)
+	}
+	registry := ref[:slash]
+	rest := ref[slash+1:]
+
+	tag := "latest"
+	if colon := strings.LastIndex(rest, ":"); colon != -1 {
+		tag = rest[colon+1:]
+		rest = rest[:colon]
+	}
+	if rest == "" {
+		return "", "", "", fmt.Errorf("invalid OCI reference: %s", templateURL)
+	}
+	return registry, rest, tag, nil
+}
+
+// registryBaseURL : OCI registries are always served over HTTPS, except for the hosts "localhost"
+// and "127.0.0.1", which are treated as local/insecure registries - e.g. one started for local
+// development or testing. Other loopback forms (such as ::1) still use HTTPS.
+func registryBaseURL(registry string) string {
+	host := registry
+	if idx := strings.Index(host, ":"); idx != -1 {
+		host = host[:idx]
+	}
+	if host == "localhost" || host == "127.0.0.1" {
+		return "http://" + registry
+	}
+	return "https://" + registry
+}
+
+// fetchOCIManifest : Fetches the manifest for repository:tag from registry's v2 API
+func fetchOCIManifest(registry string, repository string, tag string) ([]byte, error) {
+	manifestURL := fmt.Sprintf("%s/v2/%s/manifests/%s", registryBaseURL(registry), repository, tag)
+	req, err := http.NewRequest("GET", manifestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.oci.image.manifest.v1+json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch OCI manifest: %s", resp.Status)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+// fetchOCIBlob : Downloads the first layer referenced by an OCI manifest
+func fetchOCIBlob(registry string, repository string, manifest []byte) ([]byte, error) {
+	digest, err := firstLayerDigest(manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	blobURL := fmt.Sprintf("%s/v2/%s/blobs/%s", registryBaseURL(registry), repository, digest)
+	resp, err := http.Get(blobURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch OCI layer %s: %s", digest, resp.Status)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+// firstLayerDigest : Returns the digest of the first layer in an OCI image manifest
+func firstLayerDigest(manifest []byte) (string, error) {
+	var parsed struct {
+		Layers []struct {
+			Digest string `json:"digest"`
+		} `json:"layers"`
+	}
+	if err := json.Unmarshal(manifest, &parsed); err != nil {
+		return "", err
+	}
+	if len(parsed.Layers) == 0 {
+		return "", errors.New("OCI manifest has no layers")
+	}
+	return parsed.Layers[0].Digest, nil
+}